@@ -0,0 +1,145 @@
+package drv8833
+
+import "math"
+
+// MotorState is the target velocity and decay mode for one motor index
+// in a Controller.Apply call
+type MotorState struct {
+	Velocity int8
+	Decay    DecayMode
+}
+
+// Controller owns N PWMDevices and addresses every motor across them as
+// a single 0..2N-1 index space (motor 2k is device k's MotorA, motor
+// 2k+1 is its MotorB), so pairs or groups of motors can be updated
+// atomically with Apply
+type Controller struct {
+	devices []*PWMDevice
+	motors  []*Motor
+}
+
+// NewController returns a Controller addressing every motor across devices
+func NewController(devices ...*PWMDevice) *Controller {
+	c := &Controller{devices: devices}
+	for _, d := range devices {
+		c.motors = append(c.motors, d.MotorA(), d.MotorB())
+	}
+	return c
+}
+
+// Motor returns the Motor at index i, 0..2N-1 across all N PWMDevices,
+// or nil if i is out of range
+func (c *Controller) Motor(i int) *Motor {
+	if !c.validIndex(i) {
+		return nil
+	}
+	return c.motors[i]
+}
+
+// Len returns the number of motors the Controller addresses (2 per PWMDevice)
+func (c *Controller) Len() int {
+	return len(c.motors)
+}
+
+func (c *Controller) validIndex(i int) bool {
+	return i >= 0 && i < len(c.motors)
+}
+
+// Apply writes every motor index in states back-to-back and wakes each
+// involved PWMDevice exactly once at the end, so e.g. a pair of motors
+// on a differential drive robot start and stop in lockstep; indices
+// outside 0..Len()-1 are ignored
+func (c *Controller) Apply(states map[int]MotorState) {
+	woken := make(map[*PWMDevice]bool, len(c.devices))
+	for i, state := range states {
+		if !c.validIndex(i) {
+			println("drv8833: Controller.Apply ignoring out-of-range motor index")
+			continue
+		}
+		m := c.motors[i]
+		m.SetDecayMode(state.Decay)
+		m.drive(state.Velocity, false)
+		woken[c.devices[i/2]] = true
+	}
+	for dev := range woken {
+		dev.Wake()
+	}
+}
+
+// MixMode selects how Differential combines linear and angular velocity
+// into left/right motor duty
+type MixMode uint8
+
+const (
+	// ArcadeMix gives angular velocity full authority over the turn
+	ArcadeMix MixMode = iota
+	// TankMix halves angular velocity's authority for gentler turns
+	TankMix
+)
+
+// Differential mixes a linear/angular command into left/right motor
+// duty for a two-wheeled (or skid-steer) drive robot
+type Differential struct {
+	Left, Right *Motor
+	Mixer       MixMode
+}
+
+// NewDifferential returns a Differential driving left and right
+func NewDifferential(left, right *Motor, mixer MixMode) *Differential {
+	return &Differential{Left: left, Right: right, Mixer: mixer}
+}
+
+// Drive mixes linear (-100..100, forward/back) and angular (-100..100,
+// right/left turn) into left/right velocities and applies them
+func (dd *Differential) Drive(linear, angular int8) {
+	l, r := mixDifferential(linear, angular, dd.Mixer)
+	dd.Left.SetVelocity(l)
+	dd.Right.SetVelocity(r)
+}
+
+func mixDifferential(linear, angular int8, mode MixMode) (left, right int8) {
+	lin, ang := float64(linear), float64(angular)
+	if mode == TankMix {
+		ang /= 2
+	}
+	l, r := lin+ang, lin-ang
+	scale := normalizeScale(l, r)
+	return int8(l * scale), int8(r * scale)
+}
+
+// Mecanum mixes a strafing/rotation command into four motors' duty for
+// a mecanum-wheeled drive robot
+type Mecanum struct {
+	FrontLeft, FrontRight, BackLeft, BackRight *Motor
+}
+
+// NewMecanum returns a Mecanum driving the four wheel motors
+func NewMecanum(frontLeft, frontRight, backLeft, backRight *Motor) *Mecanum {
+	return &Mecanum{FrontLeft: frontLeft, FrontRight: frontRight, BackLeft: backLeft, BackRight: backRight}
+}
+
+// Drive mixes x (-100..100, strafe right/left), y (-100..100,
+// forward/back) and rot (-100..100, clockwise/counter-clockwise) into
+// each wheel's velocity and applies them
+func (mc *Mecanum) Drive(x, y, rot int8) {
+	fx, fy, fr := float64(x), float64(y), float64(rot)
+	fl, frt, bl, br := fy+fx+fr, fy-fx-fr, fy-fx+fr, fy+fx-fr
+	scale := normalizeScale(fl, frt, bl, br)
+	mc.FrontLeft.SetVelocity(int8(fl * scale))
+	mc.FrontRight.SetVelocity(int8(frt * scale))
+	mc.BackLeft.SetVelocity(int8(bl * scale))
+	mc.BackRight.SetVelocity(int8(br * scale))
+}
+
+// normalizeScale returns a scale factor, at most 1, that brings the
+// largest-magnitude value in vals down to 100 without otherwise
+// distorting the ratio between them
+func normalizeScale(vals ...float64) float64 {
+	max := 100.0
+	for _, v := range vals {
+		if a := math.Abs(v); a > max {
+			max = a
+		}
+	}
+	return 100.0 / max
+}
@@ -0,0 +1,66 @@
+package drv8833
+
+import "testing"
+
+func TestMixDifferentialArcade(t *testing.T) {
+	l, r := mixDifferential(50, 50, ArcadeMix)
+	if l != 100 || r != 0 {
+		t.Errorf("mixDifferential(50, 50, Arcade) = (%v, %v), want (100, 0)", l, r)
+	}
+	l, r = mixDifferential(100, 0, ArcadeMix)
+	if l != 100 || r != 100 {
+		t.Errorf("mixDifferential(100, 0, Arcade) = (%v, %v), want (100, 100)", l, r)
+	}
+	l, r = mixDifferential(0, 100, ArcadeMix)
+	if l != 100 || r != -100 {
+		t.Errorf("mixDifferential(0, 100, Arcade) = (%v, %v), want (100, -100)", l, r)
+	}
+}
+
+func TestMixDifferentialTankHalvesAngular(t *testing.T) {
+	l, r := mixDifferential(0, 100, TankMix)
+	if l != 50 || r != -50 {
+		t.Errorf("mixDifferential(0, 100, Tank) = (%v, %v), want (50, -50)", l, r)
+	}
+}
+
+func TestMixDifferentialNeverExceedsRange(t *testing.T) {
+	for linear := int8(-100); linear < 100; linear += 25 {
+		for angular := int8(-100); angular < 100; angular += 25 {
+			for _, mode := range []MixMode{ArcadeMix, TankMix} {
+				l, r := mixDifferential(linear, angular, mode)
+				if l < -100 || l > 100 || r < -100 || r > 100 {
+					t.Errorf("mixDifferential(%v, %v, %v) = (%v, %v), out of -100..100", linear, angular, mode, l, r)
+				}
+			}
+		}
+	}
+}
+
+func TestNormalizeScale(t *testing.T) {
+	if s := normalizeScale(50, -80); s != 1 {
+		t.Errorf("normalizeScale within range = %v, want 1", s)
+	}
+	if s := normalizeScale(200, -50); s != 0.5 {
+		t.Errorf("normalizeScale(200, -50) = %v, want 0.5", s)
+	}
+	if s := normalizeScale(0, 0); s != 1 {
+		t.Errorf("normalizeScale(0, 0) = %v, want 1", s)
+	}
+}
+
+func TestControllerMotorBoundsCheck(t *testing.T) {
+	c := &Controller{}
+	if m := c.Motor(0); m != nil {
+		t.Errorf("Motor(0) on empty Controller = %v, want nil", m)
+	}
+	if m := c.Motor(-1); m != nil {
+		t.Errorf("Motor(-1) = %v, want nil", m)
+	}
+}
+
+func TestControllerApplyIgnoresOutOfRangeIndex(t *testing.T) {
+	c := &Controller{}
+	// should not panic
+	c.Apply(map[int]MotorState{5: {Velocity: 50}})
+}
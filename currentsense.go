@@ -0,0 +1,179 @@
+package drv8833
+
+import (
+	"errors"
+	"machine"
+	"sync"
+	"time"
+)
+
+// Bridge identifies one of the two h-bridges on a DRV8833
+type Bridge uint8
+
+const (
+	BridgeA Bridge = iota
+	BridgeB
+)
+
+// adcReferenceMillivolts assumes the ADCs are referenced to a typical
+// 3.3v MCU supply
+const adcReferenceMillivolts = 3300
+
+// overcurrentSampleInterval is how often WatchOvercurrent samples current
+const overcurrentSampleInterval = 2 * time.Millisecond
+
+// overcurrentFilterAlpha weights new samples in the single-pole IIR
+// filter WatchOvercurrent uses to reject PWM switching noise
+const overcurrentFilterAlpha = 0.2
+
+var errCurrentSenseNotAttached = errors.New("drv8833: current sense not attached, call AttachCurrentSense first")
+
+// currentSense holds the ADCs wired to each h-bridge's low-side sense
+// resistor and the resistor value used to convert volts to amps
+type currentSense struct {
+	adcA, adcB      machine.ADC
+	rSenseMilliOhms uint16
+}
+
+// AttachCurrentSense wires a and b, the ADCs reading the voltage across
+// each h-bridge's external low-side sense resistor, to this PWMDevice;
+// rSenseMilliOhms is that resistor's value. This enables CurrentA/B and
+// WatchOvercurrent.
+func (d *PWMDevice) AttachCurrentSense(a, b machine.ADC, rSenseMilliOhms uint16) {
+	a.Configure(machine.ADCConfig{})
+	b.Configure(machine.ADCConfig{})
+	d.sense = &currentSense{adcA: a, adcB: b, rSenseMilliOhms: rSenseMilliOhms}
+}
+
+// CurrentA samples h-bridge A's sense resistor after settleOffPhase's
+// delay and returns the coil current in milliamps; the sample is not
+// aligned to the PWM off-time (see settleOffPhase); err is
+// errCurrentSenseNotAttached if AttachCurrentSense was never called
+func (d *PWMDevice) CurrentA() (milliamps uint16, err error) {
+	if d.sense == nil {
+		return 0, errCurrentSenseNotAttached
+	}
+	settleOffPhase(d.PwmA, d.A1, d.A2, d.periodA)
+	return sampleCurrent(d.sense.adcA, d.sense.rSenseMilliOhms), nil
+}
+
+// CurrentB samples h-bridge B's sense resistor after settleOffPhase's
+// delay and returns the coil current in milliamps; the sample is not
+// aligned to the PWM off-time (see settleOffPhase); err is
+// errCurrentSenseNotAttached if AttachCurrentSense was never called
+func (d *PWMDevice) CurrentB() (milliamps uint16, err error) {
+	if d.sense == nil {
+		return 0, errCurrentSenseNotAttached
+	}
+	settleOffPhase(d.PwmB, d.B1, d.B2, d.periodB)
+	return sampleCurrent(d.sense.adcB, d.sense.rSenseMilliOhms), nil
+}
+
+// sampleCurrent converts one ADC reading across rSenseMilliOhms into milliamps
+func sampleCurrent(adc machine.ADC, rSenseMilliOhms uint16) uint16 {
+	raw := adc.Get()
+	milliVolts := uint32(raw) * adcReferenceMillivolts / 65535
+	milliamps := milliVolts * 1000 / uint32(rSenseMilliOhms)
+	if milliamps > 65535 {
+		milliamps = 65535
+	}
+	return uint16(milliamps)
+}
+
+// drivenChannel returns whichever of ch1/ch2 is actually being PWM
+// modulated (its compare value sits strictly between 0 and Top); the
+// other channel is held static at 0 (fast decay) or Top (slow decay)
+func drivenChannel(pwm PWM, ch1, ch2 uint8) uint8 {
+	top := pwm.Top()
+	if v := pwm.Get(ch1); v > 0 && v < top {
+		return ch1
+	}
+	return ch2
+}
+
+// offPhaseDelay returns the time from the start of a PWM period to the
+// middle of the driven channel's off-phase window (its on-time plus half
+// its off-time), where the low-side FET has been on long enough for
+// switching noise to have decayed; it is 0 when there is no off-phase
+func offPhaseDelay(period time.Duration, top, duty uint32) time.Duration {
+	if period <= 0 || top == 0 {
+		return 0
+	}
+	onTime := period * time.Duration(duty) / time.Duration(top)
+	offTime := period - onTime
+	if offTime <= 0 {
+		return 0
+	}
+	return onTime + offTime/2
+}
+
+// settleOffPhase sleeps for the driven channel's offPhaseDelay before the
+// ADC read; the delay is counted from this call, not from the start of a
+// PWM period, and the PWM counter is not read, so the subsequent read is
+// not aligned to the off-phase
+func settleOffPhase(pwm PWM, ch1, ch2 uint8, period time.Duration) {
+	ch := drivenChannel(pwm, ch1, ch2)
+	time.Sleep(offPhaseDelay(period, pwm.Top(), pwm.Get(ch)))
+}
+
+// iirFilter applies one step of a single-pole IIR low-pass filter,
+// weighting the new sample by alpha, to reject PWM switching noise
+func iirFilter(prev, sample, alpha float64) float64 {
+	return prev + alpha*(sample-prev)
+}
+
+// WatchOvercurrent starts a background sampler that filters CurrentA/B
+// through a small IIR filter to reject switching noise; when a bridge's
+// filtered current exceeds threshold (in milliamps) it is auto-coasted,
+// the chip is put to sleep if both bridges have tripped, and onTrip is
+// called with the offending Bridge. This gives the software current
+// chopping the DRV8833 does not do internally. It returns a stop func
+// that ends the sampler; stop is safe to call more than once.
+func (d *PWMDevice) WatchOvercurrent(threshold uint16, onTrip func(bridge Bridge)) (stop func()) {
+	stopCh := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(overcurrentSampleInterval)
+		defer ticker.Stop()
+		var filteredA, filteredB float64
+		var trippedA, trippedB bool
+		for {
+			select {
+			case <-stopCh:
+				return
+			case <-ticker.C:
+				if ma, err := d.CurrentA(); err == nil {
+					filteredA = iirFilter(filteredA, float64(ma), overcurrentFilterAlpha)
+					if !trippedA && filteredA > float64(threshold) {
+						trippedA = true
+						d.CoastA()
+						if trippedB {
+							d.Sleep()
+						}
+						if onTrip != nil {
+							onTrip(BridgeA)
+						}
+					} else if trippedA && filteredA <= float64(threshold) {
+						trippedA = false
+					}
+				}
+				if mb, err := d.CurrentB(); err == nil {
+					filteredB = iirFilter(filteredB, float64(mb), overcurrentFilterAlpha)
+					if !trippedB && filteredB > float64(threshold) {
+						trippedB = true
+						d.CoastB()
+						if trippedA {
+							d.Sleep()
+						}
+						if onTrip != nil {
+							onTrip(BridgeB)
+						}
+					} else if trippedB && filteredB <= float64(threshold) {
+						trippedB = false
+					}
+				}
+			}
+		}
+	}()
+	var stopOnce sync.Once
+	return func() { stopOnce.Do(func() { close(stopCh) }) }
+}
@@ -0,0 +1,114 @@
+package drv8833
+
+import (
+	"machine"
+	"testing"
+	"time"
+)
+
+// mockPWM is a minimal PWM implementation for exercising pure math
+// without real hardware
+type mockPWM struct {
+	top      uint32
+	values   map[uint8]uint32
+	channels map[machine.Pin]uint8
+	next     uint8
+}
+
+func newMockPWM(top uint32) *mockPWM {
+	return &mockPWM{top: top, values: map[uint8]uint32{}, channels: map[machine.Pin]uint8{}}
+}
+
+func (m *mockPWM) Configure(config machine.PWMConfig) error { return nil }
+
+// Channel hands out a distinct channel per distinct pin, like real PWM
+// hardware, so callers that drive two pins on the same mockPWM (e.g.
+// PWMStepper.Configure) don't alias onto the same channel
+func (m *mockPWM) Channel(pin machine.Pin) (uint8, error) {
+	if ch, ok := m.channels[pin]; ok {
+		return ch, nil
+	}
+	ch := m.next
+	m.next++
+	m.channels[pin] = ch
+	return ch, nil
+}
+func (m *mockPWM) Top() uint32                                { return m.top }
+func (m *mockPWM) Get(channel uint8) uint32                   { return m.values[channel] }
+func (m *mockPWM) Set(channel uint8, value uint32)            { m.values[channel] = value }
+func (m *mockPWM) SetPeriod(period uint64) error              { return nil }
+func (m *mockPWM) SetInverting(channel uint8, inverting bool) {}
+
+func TestIirFilter(t *testing.T) {
+	got := iirFilter(0, 100, 0.2)
+	if got != 20 {
+		t.Errorf("iirFilter(0, 100, 0.2) = %v, want 20", got)
+	}
+	got = iirFilter(20, 100, 0.2)
+	if got != 36 {
+		t.Errorf("iirFilter(20, 100, 0.2) = %v, want 36", got)
+	}
+	// a steady input should converge to itself
+	v := 0.0
+	for i := 0; i < 100; i++ {
+		v = iirFilter(v, 50, 0.2)
+	}
+	if v < 49.9 || v > 50.1 {
+		t.Errorf("iirFilter did not converge to steady input, got %v", v)
+	}
+}
+
+func TestDrivenChannel(t *testing.T) {
+	pwm := newMockPWM(1000)
+	pwm.Set(1, 0)
+	pwm.Set(2, 1000)
+	if ch := drivenChannel(pwm, 1, 2); ch != 2 {
+		t.Errorf("drivenChannel with ch1 static low = %v, want 2 (both static, falls back to ch2)", ch)
+	}
+	pwm.Set(1, 400)
+	pwm.Set(2, 1000)
+	if ch := drivenChannel(pwm, 1, 2); ch != 1 {
+		t.Errorf("drivenChannel with ch1 modulated = %v, want 1", ch)
+	}
+	pwm.Set(1, 0)
+	pwm.Set(2, 600)
+	if ch := drivenChannel(pwm, 1, 2); ch != 2 {
+		t.Errorf("drivenChannel with ch2 modulated = %v, want 2", ch)
+	}
+}
+
+func TestOffPhaseDelay(t *testing.T) {
+	period := 100 * time.Microsecond
+	// 40% duty: on=40us, off=60us, settle at on + off/2 = 70us
+	got := offPhaseDelay(period, 100, 40)
+	want := 70 * time.Microsecond
+	if got != want {
+		t.Errorf("offPhaseDelay(100us, 40%%) = %v, want %v", got, want)
+	}
+	if d := offPhaseDelay(period, 100, 100); d != 0 {
+		t.Errorf("offPhaseDelay at 100%% duty = %v, want 0 (no off-phase to settle into)", d)
+	}
+	if d := offPhaseDelay(0, 100, 40); d != 0 {
+		t.Errorf("offPhaseDelay with zero period = %v, want 0", d)
+	}
+}
+
+func TestWatchOvercurrentStopIsIdempotent(t *testing.T) {
+	pwmA, pwmB := newMockPWM(1000), newMockPWM(1000)
+	dev := &PWMDevice{
+		PwmA: pwmA, A1: 1, A2: 2,
+		PwmB: pwmB, B1: 1, B2: 2,
+	}
+	dev.AttachCurrentSense(machine.ADC{}, machine.ADC{}, 100)
+
+	stop := dev.WatchOvercurrent(60000, nil) // threshold far above anything the mock reports
+	time.Sleep(5 * time.Millisecond)
+
+	defer func() {
+		if r := recover(); r != nil {
+			t.Errorf("calling stop() twice panicked: %v", r)
+		}
+	}()
+	stop()
+	stop()
+}
@@ -1,7 +1,8 @@
 // Package drv8833 provides a driver for the DRV8833 dual h-bridge chip
 // able to drive DC motors, bipolar steppers, solenoids, and other inductive loads
 // The DRV8833 has a wide power supply range from 2.7v - 10.8v
-// Included are methods that seem appropriate for DC motors Run() & latching solenoids Pulse(), but not steppers
+// Included are methods that seem appropriate for DC motors Run() & latching solenoids Pulse(),
+// plus a Stepper/PWMStepper for driving a bipolar stepper across both h-bridges
 //
 // Create a PWM-aware PWMDevice or non-PWM aware Device
 //
@@ -117,17 +118,20 @@ type PWM interface {
 
 // PWMDevice is a pair of h-bridges as found on the DRV8833 chip, with PWM support
 type PWMDevice struct {
-	sleep machine.Pin // PWM not necessary
-	a1pin machine.Pin // must be PWM pin
-	a2pin machine.Pin // must be PWM pin
-	b1pin machine.Pin // must be PWM pin
-	b2pin machine.Pin // must be PWM pin
-	A1    uint8       // PWM channel used for a1
-	A2    uint8       // PWM channel used for a2
-	B1    uint8       // PWM channel used for b1
-	B2    uint8       // PWM channel used for b2
-	PwmA  PWM         // the PWM used by h-bridge A
-	PwmB  PWM         // the PWM used by h-bridge B
+	sleep machine.Pin   // PWM not necessary
+	a1pin machine.Pin   // must be PWM pin
+	a2pin machine.Pin   // must be PWM pin
+	b1pin machine.Pin   // must be PWM pin
+	b2pin machine.Pin   // must be PWM pin
+	A1    uint8         // PWM channel used for a1
+	A2    uint8         // PWM channel used for a2
+	B1    uint8         // PWM channel used for b1
+	B2    uint8         // PWM channel used for b2
+	PwmA  PWM           // the PWM used by h-bridge A
+	PwmB  PWM           // the PWM used by h-bridge B
+	sense *currentSense // optional, see AttachCurrentSense
+
+	periodA, periodB time.Duration // configured PWM period, used to time current sense reads
 }
 
 // NewWithSpeed configures two PWMs and returns a new PWMDevice given some pins and a configured PWMConfig
@@ -141,17 +145,19 @@ func NewWithSpeed(sleep, a1pin, a2pin, b1pin, b2pin machine.Pin, pwmA, pwmB PWM,
 		println("error Configuring DRV8833 pwmB: " + err.Error())
 	}
 	return PWMDevice{
-		sleep: sleep,
-		a1pin: a1pin,
-		a2pin: a2pin,
-		b1pin: b1pin,
-		b2pin: b2pin,
-		A1:    0,
-		A2:    0,
-		B1:    0,
-		B2:    0,
-		PwmA:  pwmA,
-		PwmB:  pwmB,
+		sleep:   sleep,
+		a1pin:   a1pin,
+		a2pin:   a2pin,
+		b1pin:   b1pin,
+		b2pin:   b2pin,
+		A1:      0,
+		A2:      0,
+		B1:      0,
+		B2:      0,
+		PwmA:    pwmA,
+		PwmB:    pwmB,
+		periodA: time.Duration(pwmConfA.Period),
+		periodB: time.Duration(pwmConfB.Period),
 	}
 }
 
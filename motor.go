@@ -0,0 +1,136 @@
+package drv8833
+
+import "time"
+
+// rampTick is how often RampTo updates PWM duty while walking from one
+// velocity to another
+const rampTick = 20 * time.Millisecond
+
+// Motor presents one h-bridge as a signed velocity, so callers stop
+// having to pass ch1, ch2 uint8 arguments (and remember which is which)
+// to every call; obtained from PWMDevice.MotorA/MotorB
+type Motor struct {
+	pwm      PWM
+	ch1, ch2 uint8
+	wake     func()
+	decay    DecayMode
+	velocity int8
+}
+
+func newMotor(pwm PWM, ch1, ch2 uint8, wake func()) *Motor {
+	return &Motor{pwm: pwm, ch1: ch1, ch2: ch2, wake: wake}
+}
+
+// MotorA returns a Motor wrapping h-bridge A
+func (d *PWMDevice) MotorA() *Motor {
+	return newMotor(d.PwmA, d.A1, d.A2, d.Wake)
+}
+
+// MotorB returns a Motor wrapping h-bridge B
+func (d *PWMDevice) MotorB() *Motor {
+	return newMotor(d.PwmB, d.B1, d.B2, d.Wake)
+}
+
+// SetDecayMode selects slow or fast decay for subsequent velocity
+// changes; see DRV8833 datasheet Section 7.3.2
+func (m *Motor) SetDecayMode(mode DecayMode) {
+	m.decay = mode
+}
+
+// Velocity returns the motor's last commanded velocity, -100..100
+func (m *Motor) Velocity() int8 {
+	return m.velocity
+}
+
+// SetVelocity drives the motor immediately at v, -100 (full reverse) to
+// 100 (full forward); 0 coasts
+func (m *Motor) SetVelocity(v int8) {
+	m.drive(v, true)
+}
+
+// drive sets the duty/decay registers for v; wake controls whether the
+// owning PWMDevice is woken immediately (Controller.Apply wakes devices
+// itself, once, after setting every motor it touches)
+func (m *Motor) drive(v int8, wake bool) {
+	if v > 100 {
+		v = 100
+	} else if v < -100 {
+		v = -100
+	}
+	m.velocity = v
+	if v == 0 {
+		m.pwm.Set(m.ch1, 0)
+		m.pwm.Set(m.ch2, 0)
+	} else {
+		duty := uint32(v)
+		driveCh, recircCh := m.ch1, m.ch2
+		if v < 0 {
+			duty = uint32(-v)
+			driveCh, recircCh = m.ch2, m.ch1
+		}
+		m.pwm.Set(driveCh, m.pwm.Top()*duty/100)
+		if m.decay == SlowDecay {
+			m.pwm.Set(recircCh, m.pwm.Top())
+		} else {
+			m.pwm.Set(recircCh, 0)
+		}
+	}
+	if wake && m.wake != nil {
+		m.wake()
+	}
+}
+
+// Brake pulls both channels high for a hard stop
+func (m *Motor) Brake() {
+	m.pwm.Set(m.ch1, m.pwm.Top())
+	m.pwm.Set(m.ch2, m.pwm.Top())
+	m.velocity = 0
+}
+
+// Coast de-energizes both channels, letting the motor spin freely
+func (m *Motor) Coast() {
+	m.pwm.Set(m.ch1, 0)
+	m.pwm.Set(m.ch2, 0)
+	m.velocity = 0
+}
+
+// RampTo walks velocity linearly from its current value to target over
+// the duration over, updating duty every rampTick so the motor does not
+// jump directly between full-forward and full-reverse (which risks a
+// current spike or stall); it blocks until the ramp completes. When over
+// is zero or negative, or shorter than two rampTicks, target is set in a
+// single update.
+func (m *Motor) RampTo(target int8, over time.Duration) {
+	start := m.velocity
+	if start == target || over <= 0 {
+		m.drive(target, true)
+		return
+	}
+	tickInterval, ticks, step := rampPlan(start, target, over)
+	ticker := time.NewTicker(tickInterval)
+	defer ticker.Stop()
+	for i := 1; i <= ticks; i++ {
+		<-ticker.C
+		v := target
+		if i < ticks {
+			v = int8(float64(start) + step*float64(i))
+		}
+		m.drive(v, true)
+	}
+}
+
+// rampPlan computes how RampTo should walk from start to target over
+// dur: tickInterval is how often to update duty, ticks is how many
+// updates to make, and step is the velocity change per tick. It scales
+// tickInterval down, rather than letting dur stretch, when dur is
+// shorter than a single rampTick.
+func rampPlan(start, target int8, dur time.Duration) (tickInterval time.Duration, ticks int, step float64) {
+	tickInterval = rampTick
+	ticks = int(dur / rampTick)
+	if ticks < 1 {
+		ticks = 1
+		tickInterval = dur
+	}
+	step = float64(int(target)-int(start)) / float64(ticks)
+	return
+}
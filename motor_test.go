@@ -0,0 +1,73 @@
+package drv8833
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRampPlanSubTickDurationScalesIntervalDown(t *testing.T) {
+	tickInterval, ticks, _ := rampPlan(0, 100, 5*time.Millisecond)
+	if ticks != 1 {
+		t.Fatalf("rampPlan over 5ms ticks = %v, want 1", ticks)
+	}
+	if tickInterval != 5*time.Millisecond {
+		t.Errorf("rampPlan over 5ms tickInterval = %v, want 5ms (ramp must not stretch past dur)", tickInterval)
+	}
+}
+
+func TestRampPlanMultiTickUsesFixedRampTick(t *testing.T) {
+	tickInterval, ticks, step := rampPlan(0, 100, 100*time.Millisecond)
+	if tickInterval != rampTick {
+		t.Errorf("rampPlan over 100ms tickInterval = %v, want %v", tickInterval, rampTick)
+	}
+	wantTicks := int(100 * time.Millisecond / rampTick)
+	if ticks != wantTicks {
+		t.Errorf("rampPlan over 100ms ticks = %v, want %v", ticks, wantTicks)
+	}
+	if total := float64(ticks) * step; total != 100 {
+		t.Errorf("rampPlan total velocity change = %v, want 100", total)
+	}
+}
+
+func TestRampPlanTotalDurationNeverExceedsRequested(t *testing.T) {
+	for _, dur := range []time.Duration{1 * time.Millisecond, 10 * time.Millisecond, 19 * time.Millisecond, 25 * time.Millisecond, 50 * time.Millisecond} {
+		tickInterval, ticks, _ := rampPlan(-100, 100, dur)
+		total := tickInterval * time.Duration(ticks)
+		if total > dur {
+			t.Errorf("rampPlan(%v) total ramp time = %v, exceeds requested %v", dur, total, dur)
+		}
+	}
+}
+
+func TestRampPlanStepSign(t *testing.T) {
+	_, _, step := rampPlan(50, -50, 100*time.Millisecond)
+	if step >= 0 {
+		t.Errorf("rampPlan(50, -50) step = %v, want negative", step)
+	}
+}
+
+func TestMotorDriveClampsAndTracksVelocity(t *testing.T) {
+	pwm := newMockPWM(1000)
+	m := newMotor(pwm, 1, 2, func() {})
+
+	m.SetVelocity(127)
+	if m.Velocity() != 100 {
+		t.Errorf("SetVelocity(127) clamped to %v, want 100", m.Velocity())
+	}
+	if got := pwm.Get(1); got != 1000 {
+		t.Errorf("ch1 duty at full forward = %v, want 1000", got)
+	}
+
+	m.SetVelocity(-128)
+	if m.Velocity() != -100 {
+		t.Errorf("SetVelocity(-128) clamped to %v, want -100", m.Velocity())
+	}
+	if got := pwm.Get(2); got != 1000 {
+		t.Errorf("ch2 duty at full reverse = %v, want 1000", got)
+	}
+
+	m.SetVelocity(0)
+	if pwm.Get(1) != 0 || pwm.Get(2) != 0 {
+		t.Errorf("SetVelocity(0) should coast both channels, got (%v, %v)", pwm.Get(1), pwm.Get(2))
+	}
+}
@@ -0,0 +1,204 @@
+package drv8833
+
+import "time"
+
+// Step is one sample of a motion profile: drive at Duty % for Duration,
+// recirculating according to Decay when off
+type Step struct {
+	Duty     uint8
+	Decay    DecayMode
+	Duration time.Duration
+}
+
+// Sequence is an ordered motion profile played back by PlaySequenceA/B;
+// Repeat is how many times Steps plays through, 0 meaning loop forever
+type Sequence struct {
+	Steps  []Step
+	Repeat uint32
+}
+
+// Playback represents a Sequence running in the background on one
+// h-bridge; obtained from PlaySequenceA/PlaySequenceB
+type Playback struct {
+	pauseCh    chan bool
+	stopCh     chan struct{}
+	doneCh     chan struct{}
+	finishedCh chan struct{}
+	pwm        PWM
+	ch1, ch2   uint8
+	wake       func()
+}
+
+// playSequence drives pwm/ch1/ch2 through seq in the background,
+// calling wake before the first step so the chip leaves sleep
+func playSequence(pwm PWM, ch1, ch2 uint8, wake func(), seq Sequence, dir Direction) *Playback {
+	p := &Playback{
+		pauseCh:    make(chan bool),
+		stopCh:     make(chan struct{}),
+		doneCh:     make(chan struct{}),
+		finishedCh: make(chan struct{}),
+		pwm:        pwm,
+		ch1:        ch1,
+		ch2:        ch2,
+		wake:       wake,
+	}
+	go func() {
+		defer close(p.doneCh)
+		stopped := runSteps(pwm, ch1, ch2, wake, seq, dir, p.stopCh, p.pauseCh)
+		pwm.Set(ch1, 0)
+		pwm.Set(ch2, 0)
+		if !stopped {
+			close(p.finishedCh)
+		}
+	}()
+	return p
+}
+
+// runSteps walks seq's Steps, Repeat times (or forever if Repeat is 0),
+// returning true if it was cut short by a stop signal
+func runSteps(pwm PWM, ch1, ch2 uint8, wake func(), seq Sequence, dir Direction, stopCh chan struct{}, pauseCh chan bool) bool {
+	for count := uint32(0); seq.Repeat == 0 || count < seq.Repeat; count++ {
+		for _, step := range seq.Steps {
+			wake()
+			setStepDuty(pwm, ch1, ch2, step, dir)
+			if waitStep(step.Duration, stopCh, pauseCh) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// setStepDuty applies one Step's duty/decay to pwm's channels, with ch1
+// carrying the duty for Forward and ch2 carrying it for Reverse
+func setStepDuty(pwm PWM, ch1, ch2 uint8, step Step, dir Direction) {
+	duty := step.Duty
+	if duty > 100 {
+		duty = 100
+	}
+	on := pwm.Top() * uint32(duty) / 100
+	driveCh, recircCh := ch1, ch2
+	if dir == Reverse {
+		driveCh, recircCh = ch2, ch1
+	}
+	pwm.Set(driveCh, on)
+	if step.Decay == SlowDecay {
+		pwm.Set(recircCh, pwm.Top())
+	} else {
+		pwm.Set(recircCh, 0)
+	}
+}
+
+// waitStep blocks for dur, honoring pause/resume signals on pauseCh and
+// returning true immediately if stopCh fires
+func waitStep(dur time.Duration, stopCh chan struct{}, pauseCh chan bool) bool {
+	timer := time.NewTimer(dur)
+	started := time.Now()
+	remaining := dur
+	paused := false
+	for {
+		select {
+		case <-timer.C:
+			if !paused {
+				return false
+			}
+		case <-stopCh:
+			if !paused && !timer.Stop() {
+				<-timer.C
+			}
+			return true
+		case pause := <-pauseCh:
+			if pause && !paused {
+				paused = true
+				if !timer.Stop() {
+					<-timer.C
+				}
+				remaining -= time.Since(started)
+			} else if !pause && paused {
+				paused = false
+				started = time.Now()
+				timer = time.NewTimer(remaining)
+			}
+		}
+	}
+}
+
+// Stop cancels playback, coasting the h-bridge; safe to call more than
+// once and after playback has already finished
+func (p *Playback) Stop() {
+	select {
+	case p.stopCh <- struct{}{}:
+	case <-p.doneCh:
+	}
+}
+
+// Pause freezes playback at the current step's duty
+func (p *Playback) Pause() {
+	select {
+	case p.pauseCh <- true:
+	case <-p.doneCh:
+	}
+}
+
+// Resume continues playback from where Pause left off
+func (p *Playback) Resume() {
+	select {
+	case p.pauseCh <- false:
+	case <-p.doneCh:
+	}
+}
+
+// Done returns a channel that's closed once playback stops, whether by
+// finishing its repeats or being Stopped
+func (p *Playback) Done() <-chan struct{} {
+	return p.doneCh
+}
+
+// ThenPlay queues seq to start playing, in dir, as soon as this
+// Playback finishes its repeats naturally; a Stop does not trigger it.
+// It returns the chained Sequence's Playback immediately, letting
+// callers compose acceleration ramps, cruise, and deceleration segments
+// without blocking
+func (p *Playback) ThenPlay(seq Sequence, dir Direction) *Playback {
+	next := &Playback{
+		pauseCh:    make(chan bool),
+		stopCh:     make(chan struct{}),
+		doneCh:     make(chan struct{}),
+		finishedCh: make(chan struct{}),
+		pwm:        p.pwm,
+		ch1:        p.ch1,
+		ch2:        p.ch2,
+		wake:       p.wake,
+	}
+	go func() {
+		<-p.doneCh
+		select {
+		case <-p.finishedCh:
+		default:
+			close(next.doneCh) // p was Stopped, not chaining; next never ran
+			return
+		}
+		defer close(next.doneCh)
+		stopped := runSteps(next.pwm, next.ch1, next.ch2, next.wake, seq, dir, next.stopCh, next.pauseCh)
+		next.pwm.Set(next.ch1, 0)
+		next.pwm.Set(next.ch2, 0)
+		if !stopped {
+			close(next.finishedCh)
+		}
+	}()
+	return next
+}
+
+// PlaySequenceA plays seq in the background on h-bridge A in the given
+// Direction; this replaces blocking PulseA calls for anything more than
+// a fixed on-time
+func (d *PWMDevice) PlaySequenceA(seq Sequence, dir Direction) *Playback {
+	return playSequence(d.PwmA, d.A1, d.A2, d.Wake, seq, dir)
+}
+
+// PlaySequenceB plays seq in the background on h-bridge B in the given
+// Direction; this replaces blocking PulseB calls for anything more than
+// a fixed on-time
+func (d *PWMDevice) PlaySequenceB(seq Sequence, dir Direction) *Playback {
+	return playSequence(d.PwmB, d.B1, d.B2, d.Wake, seq, dir)
+}
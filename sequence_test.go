@@ -0,0 +1,167 @@
+package drv8833
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSetStepDuty(t *testing.T) {
+	pwm := newMockPWM(1000)
+	setStepDuty(pwm, 1, 2, Step{Duty: 40, Decay: FastDecay}, Forward)
+	if got := pwm.Get(1); got != 400 {
+		t.Errorf("Forward/FastDecay ch1 = %v, want 400", got)
+	}
+	if got := pwm.Get(2); got != 0 {
+		t.Errorf("Forward/FastDecay ch2 = %v, want 0 (coast)", got)
+	}
+
+	setStepDuty(pwm, 1, 2, Step{Duty: 40, Decay: SlowDecay}, Reverse)
+	if got := pwm.Get(2); got != 400 {
+		t.Errorf("Reverse/SlowDecay ch2 = %v, want 400", got)
+	}
+	if got := pwm.Get(1); got != pwm.Top() {
+		t.Errorf("Reverse/SlowDecay ch1 = %v, want Top() (braked)", got)
+	}
+
+	setStepDuty(pwm, 1, 2, Step{Duty: 150}, Forward)
+	if got := pwm.Get(1); got != pwm.Top() {
+		t.Errorf("Duty > 100 should clamp, ch1 = %v, want Top()", got)
+	}
+}
+
+func TestWaitStep(t *testing.T) {
+	stopCh := make(chan struct{})
+	pauseCh := make(chan bool)
+
+	if stopped := waitStep(time.Millisecond, stopCh, pauseCh); stopped {
+		t.Error("waitStep should return false when it times out normally")
+	}
+
+	go close(stopCh)
+	if stopped := waitStep(time.Hour, stopCh, pauseCh); !stopped {
+		t.Error("waitStep should return true when stopCh fires")
+	}
+}
+
+func TestWaitStepPauseResume(t *testing.T) {
+	stopCh := make(chan struct{})
+	pauseCh := make(chan bool)
+	done := make(chan bool)
+	go func() {
+		done <- waitStep(5*time.Millisecond, stopCh, pauseCh)
+	}()
+	pauseCh <- true
+	time.Sleep(10 * time.Millisecond) // well past dur, but paused should hold it open
+	select {
+	case <-done:
+		t.Fatal("waitStep returned while paused")
+	default:
+	}
+	pauseCh <- false
+	select {
+	case stopped := <-done:
+		if stopped {
+			t.Error("waitStep should return false after resuming to completion")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("waitStep never returned after resume")
+	}
+}
+
+func TestRunStepsStop(t *testing.T) {
+	pwm := newMockPWM(1000)
+	stopCh := make(chan struct{})
+	pauseCh := make(chan bool)
+	seq := Sequence{Steps: []Step{{Duty: 50, Duration: time.Hour}}, Repeat: 0}
+	go close(stopCh)
+	if stopped := runSteps(pwm, 1, 2, func() {}, seq, Forward, stopCh, pauseCh); !stopped {
+		t.Error("runSteps should report stopped when stopCh fires")
+	}
+}
+
+func TestPlaySequenceStopCoastsAndSignalsDone(t *testing.T) {
+	pwm := newMockPWM(1000)
+	woke := false
+	seq := Sequence{Steps: []Step{{Duty: 100, Duration: time.Hour}}, Repeat: 0}
+	p := playSequence(pwm, 1, 2, func() { woke = true }, seq, Forward)
+
+	time.Sleep(5 * time.Millisecond)
+	p.Stop()
+	select {
+	case <-p.Done():
+	case <-time.After(time.Second):
+		t.Fatal("Playback.Done() never closed after Stop")
+	}
+	if !woke {
+		t.Error("playSequence should call wake before its first step")
+	}
+	if pwm.Get(1) != 0 || pwm.Get(2) != 0 {
+		t.Errorf("Stop should coast both channels, got ch1=%v ch2=%v", pwm.Get(1), pwm.Get(2))
+	}
+	// Stop is safe to call again after playback has finished
+	p.Stop()
+}
+
+func TestPlaySequencePauseResume(t *testing.T) {
+	pwm := newMockPWM(1000)
+	seq := Sequence{Steps: []Step{{Duty: 100, Duration: 5 * time.Millisecond}}, Repeat: 1}
+	p := playSequence(pwm, 1, 2, func() {}, seq, Forward)
+	p.Pause()
+	select {
+	case <-p.Done():
+		t.Fatal("Playback finished while paused")
+	case <-time.After(20 * time.Millisecond):
+	}
+	p.Resume()
+	select {
+	case <-p.Done():
+	case <-time.After(time.Second):
+		t.Fatal("Playback never finished after Resume")
+	}
+}
+
+func TestThenPlayChainsAfterNaturalFinish(t *testing.T) {
+	pwm := newMockPWM(1000)
+	first := Sequence{Steps: []Step{{Duty: 50, Duration: time.Millisecond}}, Repeat: 1}
+	second := Sequence{Steps: []Step{{Duty: 100, Duration: time.Millisecond}}, Repeat: 1}
+	p := playSequence(pwm, 1, 2, func() {}, first, Forward)
+	next := p.ThenPlay(second, Forward)
+
+	select {
+	case <-next.Done():
+	case <-time.After(time.Second):
+		t.Fatal("chained Playback never completed")
+	}
+}
+
+func TestThenPlayAfterStopDoesNotHang(t *testing.T) {
+	pwm := newMockPWM(1000)
+	first := Sequence{Steps: []Step{{Duty: 50, Duration: time.Hour}}, Repeat: 0}
+	second := Sequence{Steps: []Step{{Duty: 100, Duration: time.Millisecond}}, Repeat: 1}
+	p := playSequence(pwm, 1, 2, func() {}, first, Forward)
+	next := p.ThenPlay(second, Forward)
+
+	p.Stop()
+
+	// next never ran because p was stopped rather than finishing, but
+	// next.Done() must still close so callers aren't stuck forever
+	select {
+	case <-next.Done():
+	case <-time.After(time.Second):
+		t.Fatal("chained Playback's Done() never closed after parent was Stopped")
+	}
+
+	// Stop/Pause/Resume on the never-run chained Playback must not block
+	done := make(chan struct{})
+	go func() {
+		next.Stop()
+		next.Pause()
+		next.Resume()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Stop/Pause/Resume on a never-run chained Playback hung")
+	}
+}
@@ -0,0 +1,329 @@
+package drv8833
+
+import (
+	"machine"
+	"math"
+	"time"
+)
+
+// Direction indicates which way a Stepper or Motor should turn.
+type Direction int8
+
+const (
+	Forward Direction = 1
+	Reverse Direction = -1
+)
+
+// DecayMode selects how a coil's current recirculates once PWM drive is
+// removed; see DRV8833 datasheet Section 7.3.2
+type DecayMode uint8
+
+const (
+	SlowDecay DecayMode = iota
+	FastDecay
+)
+
+// StepMode selects how finely a stepper's electrical cycle is divided;
+// FullStep and HalfStep work on both Device and PWMDevice, the
+// microstep modes require PWM to shape coil current
+type StepMode uint8
+
+const (
+	FullStep StepMode = iota
+	HalfStep
+	Microstep8
+	Microstep16
+	Microstep32
+)
+
+// stepsPerCycle returns the number of phase positions in one full
+// electrical cycle (4 full steps) for the StepMode
+func (m StepMode) stepsPerCycle() int {
+	switch m {
+	case HalfStep:
+		return 8
+	case Microstep8:
+		return 8 * 4
+	case Microstep16:
+		return 16 * 4
+	case Microstep32:
+		return 32 * 4
+	default:
+		return 4
+	}
+}
+
+// fullStepTable drives both coils on every step for maximum torque
+var fullStepTable = [4][4]bool{
+	{true, false, true, false},
+	{true, false, false, true},
+	{false, true, false, true},
+	{false, true, true, false},
+}
+
+// halfStepTable alternates single- and dual-coil states for twice the
+// angular resolution of fullStepTable at the same current
+var halfStepTable = [8][4]bool{
+	{true, false, false, false},
+	{true, false, true, false},
+	{false, false, true, false},
+	{false, true, true, false},
+	{false, true, false, false},
+	{false, true, false, true},
+	{false, false, false, true},
+	{true, false, false, true},
+}
+
+func setPin(pin machine.Pin, high bool) {
+	if high {
+		pin.High()
+	} else {
+		pin.Low()
+	}
+}
+
+// Stepper drives a bipolar stepper motor across both h-bridges of a
+// non-PWM Device; only FullStep and HalfStep are supported since coil
+// current can't be shaped without PWM, use PWMStepper for microstepping
+type Stepper struct {
+	dev       *Device
+	mode      StepMode
+	slowDecay bool
+	phase     int
+	position  int
+}
+
+// NewStepper returns a new Stepper driving dev; mode must be FullStep or
+// HalfStep, anything else falls back to FullStep
+func NewStepper(dev *Device, mode StepMode, slowDecay bool) *Stepper {
+	if mode != FullStep && mode != HalfStep {
+		println("drv8833: Stepper only supports FullStep/HalfStep, use NewPWMStepper for microstepping")
+		mode = FullStep
+	}
+	return &Stepper{dev: dev, mode: mode, slowDecay: slowDecay}
+}
+
+// Position returns the stepper's absolute position in steps from
+// wherever it started
+func (s *Stepper) Position() int {
+	return s.position
+}
+
+// Step advances the stepper n steps in the given Direction, energizing
+// coils directly; pace calls with time.Sleep to control speed
+func (s *Stepper) Step(n int, dir Direction) {
+	steps := s.mode.stepsPerCycle()
+	for i := 0; i < n; i++ {
+		s.phase = ((s.phase+int(dir))%steps + steps) % steps
+		s.applyPhase()
+		s.position += int(dir)
+	}
+}
+
+func (s *Stepper) applyPhase() {
+	var row [4]bool
+	if s.mode == HalfStep {
+		row = halfStepTable[s.phase]
+	} else {
+		row = fullStepTable[s.phase]
+	}
+	a1, a2, b1, b2 := stepperRecirculation(row, s.slowDecay)
+	setPin(s.dev.a1pin, a1)
+	setPin(s.dev.a2pin, a2)
+	setPin(s.dev.b1pin, b1)
+	setPin(s.dev.b2pin, b2)
+	if s.dev.sleep.Get() == false {
+		s.dev.Wake()
+	}
+}
+
+// stepperRecirculation applies slowDecay to a phase table row: in
+// HalfStep, one bridge is briefly unused (both its pins low, i.e.
+// coast/fast decay); with slowDecay set, that bridge is braked (both
+// pins high) instead for slow-decay recirculation. FullStep never
+// leaves a bridge unused, so slowDecay has no effect in that mode.
+func stepperRecirculation(row [4]bool, slowDecay bool) (a1, a2, b1, b2 bool) {
+	a1, a2, b1, b2 = row[0], row[1], row[2], row[3]
+	if !slowDecay {
+		return
+	}
+	if !a1 && !a2 {
+		a1, a2 = true, true
+	}
+	if !b1 && !b2 {
+		b1, b2 = true, true
+	}
+	return
+}
+
+// Release de-energizes both h-bridges (coast) and puts the chip to sleep
+func (s *Stepper) Release() {
+	s.dev.CoastA()
+	s.dev.CoastB()
+	s.dev.Sleep()
+}
+
+// PWMStepper drives a bipolar stepper motor across both h-bridges of a
+// PWMDevice, supporting full, half, and 8/16/32 microstep modes; the
+// microstep modes feed the coils sine/cosine duty tables built at
+// Configure time, FullStep/HalfStep reuse Stepper's digital phase tables
+type PWMStepper struct {
+	dev      *PWMDevice
+	mode     StepMode
+	decay    DecayMode
+	phase    int
+	position int
+	holdPct  uint8
+	aTable   []int8 // signed duty for h-bridge A at each phase, -100..100
+	bTable   []int8 // signed duty for h-bridge B at each phase, -100..100
+}
+
+// NewPWMStepper returns a new PWMStepper; call Configure before use
+func NewPWMStepper(dev *PWMDevice, mode StepMode, decay DecayMode) *PWMStepper {
+	return &PWMStepper{dev: dev, mode: mode, decay: decay, holdPct: 100}
+}
+
+// Configure configures the underlying PWMDevice and builds this
+// PWMStepper's duty tables: FullStep/HalfStep reuse the same phase
+// tables as the digital Stepper (just scaled to PWM duty), and the
+// microstep modes build sine/cosine duty tables
+func (s *PWMStepper) Configure() {
+	s.dev.Configure()
+	if s.mode == FullStep || s.mode == HalfStep {
+		s.aTable, s.bTable = buildDigitalPhaseTables(s.mode)
+	} else {
+		s.aTable, s.bTable = buildMicrostepTables(s.mode.stepsPerCycle())
+	}
+}
+
+// buildDigitalPhaseTables converts fullStepTable/halfStepTable's per-pin
+// on/off rows into signed PWM duty (-100..100) for h-bridges A and B, so
+// FullStep/HalfStep energize the same coils, in the same direction and at
+// full duty, as Stepper does at each phase
+func buildDigitalPhaseTables(mode StepMode) (aTable, bTable []int8) {
+	table := fullStepTable[:]
+	if mode == HalfStep {
+		table = halfStepTable[:]
+	}
+	aTable = make([]int8, len(table))
+	bTable = make([]int8, len(table))
+	for i, row := range table {
+		aTable[i] = pairDuty(row[0], row[1])
+		bTable[i] = pairDuty(row[2], row[3])
+	}
+	return
+}
+
+// pairDuty converts one bridge's two digital pin states into signed PWM
+// duty: pin1 high drives +100, pin2 high drives -100, neither is 0
+func pairDuty(pin1, pin2 bool) int8 {
+	switch {
+	case pin1 && !pin2:
+		return 100
+	case !pin1 && pin2:
+		return -100
+	default:
+		return 0
+	}
+}
+
+// buildMicrostepTables returns the signed duty (-100..100) for h-bridges
+// A and B at each of steps phase positions around one electrical cycle,
+// so each coil's current approximates a sine wave 90 degrees out of
+// phase with the other
+func buildMicrostepTables(steps int) (aTable, bTable []int8) {
+	aTable = make([]int8, steps)
+	bTable = make([]int8, steps)
+	for i := 0; i < steps; i++ {
+		angle := 2 * math.Pi * float64(i) / float64(steps)
+		aTable[i] = int8(math.Round(100 * math.Sin(angle)))
+		bTable[i] = int8(math.Round(100 * math.Cos(angle)))
+	}
+	return
+}
+
+// Position returns the stepper's absolute position, in steps of its
+// StepMode (full, half or micro steps), from wherever it started
+func (s *PWMStepper) Position() int {
+	return s.position
+}
+
+// Step advances the stepper n steps of its StepMode (full, half or micro
+// steps) in the given Direction; pace calls with time.Sleep, or use
+// StepAt, to control speed
+func (s *PWMStepper) Step(n int, dir Direction) {
+	steps := len(s.aTable)
+	for i := 0; i < n; i++ {
+		s.phase = ((s.phase+int(dir))%steps + steps) % steps
+		s.applyPhase()
+		s.position += int(dir)
+	}
+}
+
+// StepAt steps the stepper n steps of its StepMode in the given
+// Direction, blocking for stepInterval after each step
+func (s *PWMStepper) StepAt(n int, dir Direction, stepInterval time.Duration) {
+	for i := 0; i < n; i++ {
+		s.Step(1, dir)
+		time.Sleep(stepInterval)
+	}
+}
+
+func (s *PWMStepper) applyPhase() {
+	s.drivePhase(s.aTable[s.phase], s.dev.PwmA, s.dev.A1, s.dev.A2)
+	s.drivePhase(s.bTable[s.phase], s.dev.PwmB, s.dev.B1, s.dev.B2)
+	s.dev.Wake()
+}
+
+// drivePhase energizes one h-bridge's coil according to a signed duty
+// (-100..100) scaled by the current hold percentage, respecting the
+// configured DecayMode for recirculation
+func (s *PWMStepper) drivePhase(duty int8, pwm PWM, ch1, ch2 uint8) {
+	mag := uint32(duty)
+	if duty < 0 {
+		mag = uint32(-duty)
+	}
+	mag = mag * uint32(s.holdPct) / 100
+	on := pwm.Top() * mag / 100
+	switch {
+	case duty > 0:
+		pwm.Set(ch1, on)
+		if s.decay == SlowDecay {
+			pwm.Set(ch2, pwm.Top())
+		} else {
+			pwm.Set(ch2, 0)
+		}
+	case duty < 0:
+		pwm.Set(ch2, on)
+		if s.decay == SlowDecay {
+			pwm.Set(ch1, pwm.Top())
+		} else {
+			pwm.Set(ch1, 0)
+		}
+	default:
+		if s.decay == SlowDecay {
+			pwm.Set(ch1, pwm.Top())
+			pwm.Set(ch2, pwm.Top())
+		} else {
+			pwm.Set(ch1, 0)
+			pwm.Set(ch2, 0)
+		}
+	}
+}
+
+// SetHold scales all subsequent phase duty cycles by currentPercent,
+// letting callers reduce holding current (and heat) while stopped
+func (s *PWMStepper) SetHold(currentPercent uint8) {
+	if currentPercent > 100 {
+		currentPercent = 100
+	}
+	s.holdPct = currentPercent
+	s.applyPhase()
+}
+
+// Release de-energizes both h-bridges (coast) and puts the chip to sleep
+func (s *PWMStepper) Release() {
+	s.dev.CoastA()
+	s.dev.CoastB()
+	s.dev.Sleep()
+}
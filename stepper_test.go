@@ -0,0 +1,171 @@
+package drv8833
+
+import "testing"
+
+func TestStepModeStepsPerCycle(t *testing.T) {
+	cases := []struct {
+		mode StepMode
+		want int
+	}{
+		{FullStep, 4},
+		{HalfStep, 8},
+		{Microstep8, 32},
+		{Microstep16, 64},
+		{Microstep32, 128},
+	}
+	for _, c := range cases {
+		if got := c.mode.stepsPerCycle(); got != c.want {
+			t.Errorf("StepMode(%v).stepsPerCycle() = %v, want %v", c.mode, got, c.want)
+		}
+	}
+}
+
+func TestStepperRecirculationFastDecay(t *testing.T) {
+	for phase, row := range halfStepTable {
+		a1, a2, b1, b2 := stepperRecirculation(row, false)
+		if a1 != row[0] || a2 != row[1] || b1 != row[2] || b2 != row[3] {
+			t.Errorf("fast decay should leave phase %d unchanged, got (%v,%v,%v,%v)", phase, a1, a2, b1, b2)
+		}
+	}
+}
+
+func TestStepperRecirculationSlowDecay(t *testing.T) {
+	for phase, row := range halfStepTable {
+		a1, a2, b1, b2 := stepperRecirculation(row, true)
+		aOff := !row[0] && !row[1]
+		bOff := !row[2] && !row[3]
+		if aOff && (!a1 || !a2) {
+			t.Errorf("phase %d: expected bridge A braked (both high) when unused, got (%v,%v)", phase, a1, a2)
+		}
+		if !aOff && (a1 != row[0] || a2 != row[1]) {
+			t.Errorf("phase %d: expected bridge A unchanged when in use, got (%v,%v)", phase, a1, a2)
+		}
+		if bOff && (!b1 || !b2) {
+			t.Errorf("phase %d: expected bridge B braked (both high) when unused, got (%v,%v)", phase, b1, b2)
+		}
+		if !bOff && (b1 != row[2] || b2 != row[3]) {
+			t.Errorf("phase %d: expected bridge B unchanged when in use, got (%v,%v)", phase, b1, b2)
+		}
+	}
+	// FullStep never leaves a bridge unused, so slowDecay is a no-op
+	for phase, row := range fullStepTable {
+		a1, a2, b1, b2 := stepperRecirculation(row, true)
+		if a1 != row[0] || a2 != row[1] || b1 != row[2] || b2 != row[3] {
+			t.Errorf("FullStep phase %d should be unaffected by slowDecay, got (%v,%v,%v,%v)", phase, a1, a2, b1, b2)
+		}
+	}
+}
+
+func TestPairDuty(t *testing.T) {
+	cases := []struct {
+		pin1, pin2 bool
+		want       int8
+	}{
+		{true, false, 100},
+		{false, true, -100},
+		{false, false, 0},
+	}
+	for _, c := range cases {
+		if got := pairDuty(c.pin1, c.pin2); got != c.want {
+			t.Errorf("pairDuty(%v, %v) = %v, want %v", c.pin1, c.pin2, got, c.want)
+		}
+	}
+}
+
+func TestBuildDigitalPhaseTablesMatchesDigitalStepper(t *testing.T) {
+	aTable, bTable := buildDigitalPhaseTables(FullStep)
+	if len(aTable) != len(fullStepTable) || len(bTable) != len(fullStepTable) {
+		t.Fatalf("buildDigitalPhaseTables(FullStep) length = %d/%d, want %d", len(aTable), len(bTable), len(fullStepTable))
+	}
+	for i, row := range fullStepTable {
+		if want := pairDuty(row[0], row[1]); aTable[i] != want {
+			t.Errorf("FullStep aTable[%d] = %v, want %v", i, aTable[i], want)
+		}
+		if want := pairDuty(row[2], row[3]); bTable[i] != want {
+			t.Errorf("FullStep bTable[%d] = %v, want %v", i, bTable[i], want)
+		}
+		// FullStep drives both coils every phase, for maximum torque
+		if aTable[i] == 0 || bTable[i] == 0 {
+			t.Errorf("FullStep phase %d should energize both coils, got a=%v b=%v", i, aTable[i], bTable[i])
+		}
+	}
+
+	aTable, bTable = buildDigitalPhaseTables(HalfStep)
+	if len(aTable) != len(halfStepTable) || len(bTable) != len(halfStepTable) {
+		t.Fatalf("buildDigitalPhaseTables(HalfStep) length = %d/%d, want %d", len(aTable), len(bTable), len(halfStepTable))
+	}
+	for i, row := range halfStepTable {
+		if want := pairDuty(row[0], row[1]); aTable[i] != want {
+			t.Errorf("HalfStep aTable[%d] = %v, want %v", i, aTable[i], want)
+		}
+		if want := pairDuty(row[2], row[3]); bTable[i] != want {
+			t.Errorf("HalfStep bTable[%d] = %v, want %v", i, bTable[i], want)
+		}
+	}
+}
+
+func TestPWMStepperConfigureSelectsTablesByMode(t *testing.T) {
+	dev := &PWMDevice{PwmA: newMockPWM(1000), PwmB: newMockPWM(1000)}
+
+	full := NewPWMStepper(dev, FullStep, SlowDecay)
+	full.Configure()
+	wantA, wantB := buildDigitalPhaseTables(FullStep)
+	if !equalInt8Slices(full.aTable, wantA) || !equalInt8Slices(full.bTable, wantB) {
+		t.Errorf("FullStep PWMStepper should reuse Stepper's digital phase tables, not sine/cosine wave drive")
+	}
+
+	micro := NewPWMStepper(dev, Microstep8, SlowDecay)
+	micro.Configure()
+	wantA, wantB = buildMicrostepTables(Microstep8.stepsPerCycle())
+	if !equalInt8Slices(micro.aTable, wantA) || !equalInt8Slices(micro.bTable, wantB) {
+		t.Errorf("Microstep8 PWMStepper should use sine/cosine microstep tables")
+	}
+}
+
+func TestDrivePhaseHalfStepSlowDecayBrakesIdleBridge(t *testing.T) {
+	pwmA, pwmB := newMockPWM(1000), newMockPWM(1000)
+	dev := &PWMDevice{PwmA: pwmA, PwmB: pwmB, a1pin: 1, a2pin: 2, b1pin: 3, b2pin: 4}
+	s := NewPWMStepper(dev, HalfStep, SlowDecay)
+	s.Configure()
+
+	// halfStepTable phase 2 ({false, false, true, false}) leaves bridge A
+	// idle (duty 0); SlowDecay should brake it (both pins high) rather
+	// than coast it (both pins low), matching Stepper.applyPhase.
+	s.phase = 2
+	s.applyPhase()
+	if pwmA.Get(dev.A1) != pwmA.Top() || pwmA.Get(dev.A2) != pwmA.Top() {
+		t.Errorf("idle bridge A under SlowDecay should brake to Top()/Top(), got %v/%v", pwmA.Get(dev.A1), pwmA.Get(dev.A2))
+	}
+}
+
+func equalInt8Slices(a, b []int8) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestBuildMicrostepTables(t *testing.T) {
+	aTable, bTable := buildMicrostepTables(4)
+	if len(aTable) != 4 || len(bTable) != 4 {
+		t.Fatalf("buildMicrostepTables(4) returned tables of length %d/%d, want 4/4", len(aTable), len(bTable))
+	}
+	// at phase 0 the A coil should be at rest (sin 0 = 0) and B at full
+	// scale (cos 0 = 1)
+	if aTable[0] != 0 {
+		t.Errorf("aTable[0] = %v, want 0", aTable[0])
+	}
+	if bTable[0] != 100 {
+		t.Errorf("bTable[0] = %v, want 100", bTable[0])
+	}
+	for i, duty := range append(append([]int8{}, aTable...), bTable...) {
+		if duty < -100 || duty > 100 {
+			t.Errorf("table entry %d out of range: %v", i, duty)
+		}
+	}
+}